@@ -0,0 +1,196 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package admission implements an HTTPS admission webhook for Ingress
+// objects. It reuses the same annotation parsers the controller relies on
+// during a sync so that an Ingress with an invalid annotation (a bad
+// auth-type, a malformed rate-limit, a CIDR that does not parse, a
+// auth-tls-secret that cannot be resolved) is rejected at admission time
+// instead of being accepted and only logged as broken once the controller
+// picks it up.
+package admission
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/golang/glog"
+
+	"k8s.io/api/admission/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	extensions "k8s.io/client-go/pkg/apis/extensions/v1beta1"
+	"k8s.io/client-go/tools/cache"
+
+	"k8s.io/ingressl4/core/pkg/ingress/controller"
+)
+
+// Config holds everything the webhook needs to validate an incoming
+// Ingress: the annotation extractor (shared with the controller) and a
+// read-only view of every Ingress currently known to the cluster, used to
+// reject host+path claims that already belong to another namespace.
+type Config struct {
+	Address     string
+	TLSCertFile string
+	TLSKeyFile  string
+
+	Extractor     controller.AnnotationExtractor
+	IngressLister cache.Store
+}
+
+// Server is an HTTPS admission controller for extensions/v1beta1 Ingress
+// objects.
+type Server struct {
+	cfg Config
+	srv *http.Server
+}
+
+// NewServer builds a Server ready to ListenAndServeTLS. It does not start
+// listening until Start is called.
+func NewServer(cfg Config) *Server {
+	s := &Server{cfg: cfg}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/validate", s.handle)
+
+	s.srv = &http.Server{
+		Addr:    cfg.Address,
+		Handler: mux,
+	}
+
+	return s
+}
+
+// Start begins serving admission reviews. It blocks until the server
+// fails or is closed.
+func (s *Server) Start() error {
+	cert, err := tls.LoadX509KeyPair(s.cfg.TLSCertFile, s.cfg.TLSKeyFile)
+	if err != nil {
+		return fmt.Errorf("unexpected error loading webhook certificate: %v", err)
+	}
+
+	s.srv.TLSConfig = &tls.Config{Certificates: []tls.Certificate{cert}}
+	return s.srv.ListenAndServeTLS("", "")
+}
+
+func (s *Server) handle(w http.ResponseWriter, r *http.Request) {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("unable to read request body: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	review := v1beta1.AdmissionReview{}
+	if err := json.Unmarshal(body, &review); err != nil {
+		http.Error(w, fmt.Sprintf("unable to decode admission review: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	if review.Request == nil {
+		review.Response = deny("", "admission review missing request")
+	} else {
+		review.Response = s.review(review.Request)
+	}
+
+	resp, err := json.Marshal(review)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("unable to encode admission review: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(resp)
+}
+
+// review decodes the Ingress carried by req, runs every registered
+// annotation parser against it and checks that its host+path rules do not
+// already belong to an Ingress in another namespace. It never mutates the
+// object, so the returned patch is always empty.
+func (s *Server) review(req *v1beta1.AdmissionRequest) *v1beta1.AdmissionResponse {
+	ing := extensions.Ingress{}
+	if err := json.Unmarshal(req.Object.Raw, &ing); err != nil {
+		return deny(req.UID, fmt.Sprintf("unable to decode ingress: %v", err))
+	}
+
+	if err := s.cfg.Extractor.Validate(&ing); err != nil {
+		return deny(req.UID, err.Error())
+	}
+
+	if err := s.checkHostPathOwnership(&ing); err != nil {
+		return deny(req.UID, err.Error())
+	}
+
+	return &v1beta1.AdmissionResponse{UID: req.UID, Allowed: true}
+}
+
+// checkHostPathOwnership walks the shared informer cache looking for an
+// Ingress in a different namespace that already claims one of ing's
+// host+path pairs, so a tenant cannot hijack another tenant's hostname.
+func (s *Server) checkHostPathOwnership(ing *extensions.Ingress) error {
+	if s.cfg.IngressLister == nil {
+		return nil
+	}
+
+	claims := hostPaths(ing)
+
+	for _, obj := range s.cfg.IngressLister.List() {
+		other, ok := obj.(*extensions.Ingress)
+		if !ok || other.Namespace == ing.Namespace {
+			continue
+		}
+
+		for hp := range hostPaths(other) {
+			if _, claimed := claims[hp]; claimed {
+				return fmt.Errorf("host %q already claimed by ingress %v/%v", hp, other.Namespace, other.Name)
+			}
+		}
+	}
+
+	return nil
+}
+
+func hostPaths(ing *extensions.Ingress) map[string]struct{} {
+	claims := make(map[string]struct{})
+	for _, rule := range ing.Spec.Rules {
+		if rule.HTTP == nil {
+			claims[rule.Host] = struct{}{}
+			continue
+		}
+
+		for _, path := range rule.HTTP.Paths {
+			claims[rule.Host+path.Path] = struct{}{}
+		}
+	}
+
+	return claims
+}
+
+// deny builds a rejecting AdmissionResponse. uid must be copied from the
+// AdmissionRequest being answered: the apiserver rejects any response
+// whose UID does not echo the request it belongs to.
+func deny(uid types.UID, message string) *v1beta1.AdmissionResponse {
+	glog.V(3).Infof("admission webhook rejecting ingress: %v", message)
+	return &v1beta1.AdmissionResponse{
+		UID:     uid,
+		Allowed: false,
+		Result: &metav1.Status{
+			Message: message,
+		},
+	}
+}
@@ -0,0 +1,202 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package admission
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"k8s.io/api/admission/v1beta1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	extensions "k8s.io/client-go/pkg/apis/extensions/v1beta1"
+	"k8s.io/client-go/tools/cache"
+)
+
+// fakeExtractor lets each test decide whether Validate should accept or
+// reject the Ingress, without needing a real annotation parser registry.
+type fakeExtractor struct {
+	err error
+}
+
+func (f fakeExtractor) Validate(ing *extensions.Ingress) error {
+	return f.err
+}
+
+func buildIngress(namespace, name, host, path string) *extensions.Ingress {
+	return &extensions.Ingress{
+		ObjectMeta: meta_v1.ObjectMeta{Namespace: namespace, Name: name},
+		Spec: extensions.IngressSpec{
+			Rules: []extensions.IngressRule{
+				{
+					Host: host,
+					IngressRuleValue: extensions.IngressRuleValue{
+						HTTP: &extensions.HTTPIngressRuleValue{
+							Paths: []extensions.HTTPIngressPath{{Path: path}},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func admissionRequest(t *testing.T, ing *extensions.Ingress) *v1beta1.AdmissionRequest {
+	raw, err := json.Marshal(ing)
+	if err != nil {
+		t.Fatalf("unexpected error marshaling ingress: %v", err)
+	}
+
+	return &v1beta1.AdmissionRequest{
+		UID:    types.UID("test-uid"),
+		Object: runtime.RawExtension{Raw: raw},
+	}
+}
+
+func TestReviewEchoesUID(t *testing.T) {
+	s := &Server{cfg: Config{Extractor: fakeExtractor{}}}
+	req := admissionRequest(t, buildIngress("default", "app", "app.example.com", "/"))
+
+	resp := s.review(req)
+
+	if resp.UID != req.UID {
+		t.Fatalf("expected response UID %v to echo request UID %v", resp.UID, req.UID)
+	}
+}
+
+func TestReviewAllowsValidIngress(t *testing.T) {
+	s := &Server{cfg: Config{Extractor: fakeExtractor{}}}
+	req := admissionRequest(t, buildIngress("default", "app", "app.example.com", "/"))
+
+	resp := s.review(req)
+
+	if !resp.Allowed {
+		t.Fatalf("expected ingress to be allowed, got denied: %+v", resp.Result)
+	}
+}
+
+func TestReviewDeniesInvalidAnnotations(t *testing.T) {
+	s := &Server{cfg: Config{Extractor: fakeExtractor{err: fmt.Errorf("bad auth-type")}}}
+	req := admissionRequest(t, buildIngress("default", "app", "app.example.com", "/"))
+
+	resp := s.review(req)
+
+	if resp.Allowed {
+		t.Fatalf("expected ingress with invalid annotations to be denied")
+	}
+	if resp.Result == nil || resp.Result.Message != "bad auth-type" {
+		t.Fatalf("expected parser error in Result.Message, got %+v", resp.Result)
+	}
+}
+
+func TestReviewDeniesUndecodableObject(t *testing.T) {
+	s := &Server{cfg: Config{Extractor: fakeExtractor{}}}
+	req := &v1beta1.AdmissionRequest{UID: types.UID("test-uid"), Object: runtime.RawExtension{Raw: []byte("not-json")}}
+
+	resp := s.review(req)
+
+	if resp.Allowed {
+		t.Fatalf("expected undecodable object to be denied")
+	}
+	if resp.UID != req.UID {
+		t.Fatalf("expected response UID to echo request UID even on decode failure")
+	}
+}
+
+func TestHandleDeniesMissingRequest(t *testing.T) {
+	s := &Server{cfg: Config{Extractor: fakeExtractor{}}}
+
+	body := []byte(`{"kind":"AdmissionReview","apiVersion":"admission.k8s.io/v1beta1"}`)
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/validate", bytes.NewReader(body))
+
+	s.handle(w, r)
+
+	review := v1beta1.AdmissionReview{}
+	if err := json.Unmarshal(w.Body.Bytes(), &review); err != nil {
+		t.Fatalf("unexpected error decoding response: %v", err)
+	}
+	if review.Response == nil || review.Response.Allowed {
+		t.Fatalf("expected an admission review with a nil request to be denied, got %+v", review.Response)
+	}
+}
+
+func TestReviewDeniesHostPathClaimedByAnotherNamespace(t *testing.T) {
+	store := cache.NewStore(cache.MetaNamespaceKeyFunc)
+	store.Add(buildIngress("tenant-a", "app", "shared.example.com", "/"))
+
+	s := &Server{cfg: Config{Extractor: fakeExtractor{}, IngressLister: store}}
+	req := admissionRequest(t, buildIngress("tenant-b", "app", "shared.example.com", "/"))
+
+	resp := s.review(req)
+
+	if resp.Allowed {
+		t.Fatalf("expected ingress hijacking another namespace's host+path to be denied")
+	}
+}
+
+func TestReviewAllowsHostPathOwnedBySameNamespace(t *testing.T) {
+	store := cache.NewStore(cache.MetaNamespaceKeyFunc)
+	store.Add(buildIngress("default", "app-v1", "app.example.com", "/"))
+
+	s := &Server{cfg: Config{Extractor: fakeExtractor{}, IngressLister: store}}
+	req := admissionRequest(t, buildIngress("default", "app-v2", "app.example.com", "/"))
+
+	resp := s.review(req)
+
+	if !resp.Allowed {
+		t.Fatalf("expected ingress sharing a host+path within its own namespace to be allowed, got %+v", resp.Result)
+	}
+}
+
+func TestCheckHostPathOwnershipNilLister(t *testing.T) {
+	s := &Server{cfg: Config{}}
+
+	if err := s.checkHostPathOwnership(buildIngress("default", "app", "app.example.com", "/")); err != nil {
+		t.Fatalf("expected no error when no IngressLister is configured, got %v", err)
+	}
+}
+
+func TestHostPaths(t *testing.T) {
+	ing := buildIngress("default", "app", "app.example.com", "/api")
+
+	claims := hostPaths(ing)
+
+	if _, ok := claims["app.example.com/api"]; !ok {
+		t.Fatalf("expected claims to contain host+path, got %+v", claims)
+	}
+}
+
+func TestHostPathsNoHTTPRule(t *testing.T) {
+	ing := &extensions.Ingress{
+		ObjectMeta: meta_v1.ObjectMeta{Namespace: "default", Name: "app"},
+		Spec: extensions.IngressSpec{
+			Rules: []extensions.IngressRule{{Host: "app.example.com"}},
+		},
+	}
+
+	claims := hostPaths(ing)
+
+	if _, ok := claims["app.example.com"]; !ok {
+		t.Fatalf("expected claims to contain bare host when no HTTP rule is set, got %+v", claims)
+	}
+}
@@ -0,0 +1,70 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package backendprotocol
+
+import (
+	"strings"
+
+	extensions "k8s.io/client-go/pkg/apis/extensions/v1beta1"
+
+	"k8s.io/ingressl4/core/pkg/ingress/annotations/parser"
+	"k8s.io/ingressl4/core/pkg/ingress/errors"
+)
+
+// Protocol is the wire protocol a backend expects traffic in, so the
+// controller can proxy gRPC/H2C upstreams with the right scheme and
+// HTTP/2 framing instead of forcing everything to HTTP/1.1.
+type Protocol string
+
+// Supported backend protocols.
+const (
+	HTTP  Protocol = "HTTP"
+	HTTPS Protocol = "HTTPS"
+	GRPC  Protocol = "GRPC"
+	GRPCS Protocol = "GRPCS"
+	H2C   Protocol = "H2C"
+	FCGI  Protocol = "FCGI"
+	AJP   Protocol = "AJP"
+)
+
+const backendProtocolAnnotation = "ingress.kubernetes.io/backend-protocol"
+
+type backendProtocol struct{}
+
+// NewParser creates a new backend-protocol annotation parser.
+func NewParser() parser.IngressAnnotation {
+	return backendProtocol{}
+}
+
+// Parse extracts the backend protocol from an Ingress. Ingresses without
+// the annotation return a missing-annotations error so callers can fall
+// back to their own default (HTTP, or HTTPS via the legacy
+// secure-upstream annotation).
+func (bp backendProtocol) Parse(ing *extensions.Ingress) (interface{}, error) {
+	val, err := parser.GetStringAnnotation(backendProtocolAnnotation, ing)
+	if err != nil {
+		return nil, errors.NewMissingAnnotations(backendProtocolAnnotation)
+	}
+
+	proto := Protocol(strings.ToUpper(val))
+	switch proto {
+	case HTTP, HTTPS, GRPC, GRPCS, H2C, FCGI, AJP:
+		return proto, nil
+	}
+
+	return nil, errors.NewInvalidAnnotationContent(backendProtocolAnnotation, val)
+}
@@ -0,0 +1,96 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package backendprotocol
+
+import (
+	"testing"
+
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	extensions "k8s.io/client-go/pkg/apis/extensions/v1beta1"
+
+	"k8s.io/ingressl4/core/pkg/ingress/errors"
+)
+
+func buildIngress(annotations map[string]string) *extensions.Ingress {
+	return &extensions.Ingress{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:        "backend-protocol-ingress",
+			Namespace:   "default",
+			Annotations: annotations,
+		},
+	}
+}
+
+func TestParseMissingAnnotation(t *testing.T) {
+	ing := buildIngress(map[string]string{})
+
+	_, err := NewParser().Parse(ing)
+	if err == nil {
+		t.Fatalf("expected a missing annotations error")
+	}
+	if !errors.IsMissingAnnotations(err) {
+		t.Fatalf("expected a missing annotations error, got %v", err)
+	}
+}
+
+func TestParseSupportedProtocols(t *testing.T) {
+	tests := []struct {
+		annotation string
+		expected   Protocol
+	}{
+		{"HTTP", HTTP},
+		{"HTTPS", HTTPS},
+		{"GRPC", GRPC},
+		{"GRPCS", GRPCS},
+		{"H2C", H2C},
+		{"FCGI", FCGI},
+		{"AJP", AJP},
+		{"grpc", GRPC},
+		{"h2c", H2C},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.annotation, func(t *testing.T) {
+			ing := buildIngress(map[string]string{
+				"ingress.kubernetes.io/backend-protocol": tt.annotation,
+			})
+
+			val, err := NewParser().Parse(ing)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if val.(Protocol) != tt.expected {
+				t.Errorf("expected protocol %v, got %v", tt.expected, val)
+			}
+		})
+	}
+}
+
+func TestParseInvalidProtocol(t *testing.T) {
+	ing := buildIngress(map[string]string{
+		"ingress.kubernetes.io/backend-protocol": "CARRIER-PIGEON",
+	})
+
+	_, err := NewParser().Parse(ing)
+	if err == nil {
+		t.Fatalf("expected an error for an unsupported protocol")
+	}
+	if errors.IsMissingAnnotations(err) {
+		t.Fatalf("expected an invalid content error, not a missing annotations error")
+	}
+}
@@ -0,0 +1,81 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package canary
+
+import (
+	extensions "k8s.io/client-go/pkg/apis/extensions/v1beta1"
+
+	"k8s.io/ingressl4/core/pkg/ingress/annotations/parser"
+	"k8s.io/ingressl4/core/pkg/ingress/errors"
+)
+
+const (
+	canaryAnnotation              = "ingress.kubernetes.io/canary"
+	canaryWeightAnnotation        = "ingress.kubernetes.io/canary-weight"
+	canaryByHeaderAnnotation      = "ingress.kubernetes.io/canary-by-header"
+	canaryByHeaderValueAnnotation = "ingress.kubernetes.io/canary-by-header-value"
+	canaryByCookieAnnotation      = "ingress.kubernetes.io/canary-by-cookie"
+)
+
+// Config holds the canary/traffic-split rules declared on an Ingress.
+type Config struct {
+	Enabled     bool
+	Weight      int
+	Header      string
+	HeaderValue string
+	Cookie      string
+}
+
+type canary struct{}
+
+// NewParser creates a new canary annotation parser.
+func NewParser() parser.IngressAnnotation {
+	return canary{}
+}
+
+// Parse extracts the canary annotations from an Ingress. It returns a
+// missing-annotations error when ingress.kubernetes.io/canary is not
+// "true", so an Ingress without it is left out of the merge entirely.
+func (c canary) Parse(ing *extensions.Ingress) (interface{}, error) {
+	enabled, _ := parser.GetBoolAnnotation(canaryAnnotation, ing)
+	if !enabled {
+		return nil, errors.NewMissingAnnotations(canaryAnnotation)
+	}
+
+	weight, err := parser.GetIntAnnotation(canaryWeightAnnotation, ing)
+	if err != nil {
+		if !errors.IsMissingAnnotations(err) {
+			return nil, errors.NewInvalidAnnotationContent(canaryWeightAnnotation, err)
+		}
+		weight = 0
+	}
+	if weight < 0 || weight > 100 {
+		return nil, errors.NewInvalidAnnotationContent(canaryWeightAnnotation, weight)
+	}
+
+	header, _ := parser.GetStringAnnotation(canaryByHeaderAnnotation, ing)
+	headerValue, _ := parser.GetStringAnnotation(canaryByHeaderValueAnnotation, ing)
+	cookie, _ := parser.GetStringAnnotation(canaryByCookieAnnotation, ing)
+
+	return &Config{
+		Enabled:     enabled,
+		Weight:      weight,
+		Header:      header,
+		HeaderValue: headerValue,
+		Cookie:      cookie,
+	}, nil
+}
@@ -0,0 +1,101 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package canary
+
+import (
+	"testing"
+
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	extensions "k8s.io/client-go/pkg/apis/extensions/v1beta1"
+
+	"k8s.io/ingressl4/core/pkg/ingress/errors"
+)
+
+func buildIngress(annotations map[string]string) *extensions.Ingress {
+	return &extensions.Ingress{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:        "canary-ingress",
+			Namespace:   "default",
+			Annotations: annotations,
+		},
+	}
+}
+
+func TestParseNotEnabled(t *testing.T) {
+	ing := buildIngress(map[string]string{})
+
+	_, err := NewParser().Parse(ing)
+	if err == nil {
+		t.Fatalf("expected a missing annotations error")
+	}
+	if !errors.IsMissingAnnotations(err) {
+		t.Fatalf("expected a missing annotations error, got %v", err)
+	}
+}
+
+func TestParseWeightAndMatchers(t *testing.T) {
+	ing := buildIngress(map[string]string{
+		"ingress.kubernetes.io/canary":                 "true",
+		"ingress.kubernetes.io/canary-weight":          "42",
+		"ingress.kubernetes.io/canary-by-header":       "X-Canary",
+		"ingress.kubernetes.io/canary-by-header-value": "always",
+		"ingress.kubernetes.io/canary-by-cookie":       "canary",
+	})
+
+	val, err := NewParser().Parse(ing)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	cfg := val.(*Config)
+	if !cfg.Enabled {
+		t.Errorf("expected canary to be enabled")
+	}
+	if cfg.Weight != 42 {
+		t.Errorf("expected weight 42, got %v", cfg.Weight)
+	}
+	if cfg.Header != "X-Canary" || cfg.HeaderValue != "always" {
+		t.Errorf("unexpected header matcher: %+v", cfg)
+	}
+	if cfg.Cookie != "canary" {
+		t.Errorf("expected cookie matcher canary, got %v", cfg.Cookie)
+	}
+}
+
+func TestParseInvalidWeight(t *testing.T) {
+	ing := buildIngress(map[string]string{
+		"ingress.kubernetes.io/canary":        "true",
+		"ingress.kubernetes.io/canary-weight": "101",
+	})
+
+	_, err := NewParser().Parse(ing)
+	if err == nil {
+		t.Fatalf("expected an error for an out-of-range weight")
+	}
+}
+
+func TestParseMalformedWeight(t *testing.T) {
+	ing := buildIngress(map[string]string{
+		"ingress.kubernetes.io/canary":        "true",
+		"ingress.kubernetes.io/canary-weight": "not-a-number",
+	})
+
+	_, err := NewParser().Parse(ing)
+	if err == nil {
+		t.Fatalf("expected an error for a malformed weight instead of silently defaulting to 0")
+	}
+}
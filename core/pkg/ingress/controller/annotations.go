@@ -24,9 +24,12 @@ import (
 	api "k8s.io/client-go/pkg/api/v1"
 	extensions "k8s.io/client-go/pkg/apis/extensions/v1beta1"
 
+	"k8s.io/ingressl4/core/pkg/ingress"
 	"k8s.io/ingressl4/core/pkg/ingress/annotations/auth"
 	"k8s.io/ingressl4/core/pkg/ingress/annotations/authreq"
 	"k8s.io/ingressl4/core/pkg/ingress/annotations/authtls"
+	"k8s.io/ingressl4/core/pkg/ingress/annotations/backendprotocol"
+	"k8s.io/ingressl4/core/pkg/ingress/annotations/canary"
 	"k8s.io/ingressl4/core/pkg/ingress/annotations/cors"
 	"k8s.io/ingressl4/core/pkg/ingress/annotations/healthcheck"
 	"k8s.io/ingressl4/core/pkg/ingress/annotations/ipwhitelist"
@@ -49,86 +52,282 @@ type extractorConfig interface {
 	resolver.Secret
 }
 
+// annotationEntry pairs a parser with the single place that knows how to
+// move its result into the matching field of ingress.ParsedAnnotations.
+// Declaring the type once here, instead of at every call site, is what
+// lets Extract build a typed struct instead of a map[string]interface{}.
+type annotationEntry struct {
+	parser parser.IngressAnnotation
+	assign func(pa *ingress.ParsedAnnotations, val interface{}) error
+}
+
+// typed builds an assign func for annotationEntry out of a type-checked
+// setter, so each entry below only has to name its field and type once.
+func typed(name string, set func(pa *ingress.ParsedAnnotations, val interface{}) bool) func(*ingress.ParsedAnnotations, interface{}) error {
+	return func(pa *ingress.ParsedAnnotations, val interface{}) error {
+		if !set(pa, val) {
+			return fmt.Errorf("annotation %v parser returned unexpected type %T", name, val)
+		}
+		return nil
+	}
+}
+
 type annotationExtractor struct {
 	secretResolver resolver.Secret
-	annotations    map[string]parser.IngressAnnotation
+	annotations    map[string]annotationEntry
 }
 
 func newAnnotationExtractor(cfg extractorConfig) annotationExtractor {
 	return annotationExtractor{
 		cfg,
-		map[string]parser.IngressAnnotation{
-			"BasicDigestAuth":      auth.NewParser(auth.AuthDirectory, cfg),
-			"ExternalAuth":         authreq.NewParser(),
-			"CertificateAuth":      authtls.NewParser(cfg),
-			"EnableCORS":           cors.NewParser(),
-			"HealthCheck":          healthcheck.NewParser(cfg),
-			"Whitelist":            ipwhitelist.NewParser(cfg),
-			"UsePortInRedirects":   portinredirect.NewParser(cfg),
-			"Proxy":                proxy.NewParser(cfg),
-			"RateLimit":            ratelimit.NewParser(),
-			"Redirect":             rewrite.NewParser(cfg),
-			"SecureUpstream":       secureupstream.NewParser(),
-			"SessionAffinity":      sessionaffinity.NewParser(),
-			"SSLPassthrough":       sslpassthrough.NewParser(),
-			"ConfigurationSnippet": snippet.NewParser(),
+		map[string]annotationEntry{
+			"BasicDigestAuth": {
+				auth.NewParser(auth.AuthDirectory, cfg),
+				typed("BasicDigestAuth", func(pa *ingress.ParsedAnnotations, val interface{}) bool {
+					v, ok := val.(*auth.Config)
+					pa.BasicDigestAuth = v
+					return ok
+				}),
+			},
+			"ExternalAuth": {
+				authreq.NewParser(),
+				typed("ExternalAuth", func(pa *ingress.ParsedAnnotations, val interface{}) bool {
+					v, ok := val.(*authreq.Config)
+					pa.ExternalAuth = v
+					return ok
+				}),
+			},
+			"CertificateAuth": {
+				authtls.NewParser(cfg),
+				typed("CertificateAuth", func(pa *ingress.ParsedAnnotations, val interface{}) bool {
+					v, ok := val.(*authtls.Config)
+					pa.CertificateAuth = v
+					return ok
+				}),
+			},
+			"BackendProtocol": {
+				backendprotocol.NewParser(),
+				typed("BackendProtocol", func(pa *ingress.ParsedAnnotations, val interface{}) bool {
+					v, ok := val.(backendprotocol.Protocol)
+					pa.BackendProtocol = v
+					return ok
+				}),
+			},
+			"Canary": {
+				canary.NewParser(),
+				typed("Canary", func(pa *ingress.ParsedAnnotations, val interface{}) bool {
+					v, ok := val.(*canary.Config)
+					pa.Canary = v
+					return ok
+				}),
+			},
+			"EnableCORS": {
+				cors.NewParser(),
+				typed("EnableCORS", func(pa *ingress.ParsedAnnotations, val interface{}) bool {
+					v, ok := val.(*cors.Config)
+					pa.EnableCORS = v
+					return ok
+				}),
+			},
+			"HealthCheck": {
+				healthcheck.NewParser(cfg),
+				typed("HealthCheck", func(pa *ingress.ParsedAnnotations, val interface{}) bool {
+					v, ok := val.(*healthcheck.Upstream)
+					pa.HealthCheck = v
+					return ok
+				}),
+			},
+			"Whitelist": {
+				ipwhitelist.NewParser(cfg),
+				typed("Whitelist", func(pa *ingress.ParsedAnnotations, val interface{}) bool {
+					v, ok := val.(*ipwhitelist.Config)
+					pa.Whitelist = v
+					return ok
+				}),
+			},
+			"UsePortInRedirects": {
+				portinredirect.NewParser(cfg),
+				typed("UsePortInRedirects", func(pa *ingress.ParsedAnnotations, val interface{}) bool {
+					v, ok := val.(bool)
+					pa.UsePortInRedirects = v
+					return ok
+				}),
+			},
+			"Proxy": {
+				proxy.NewParser(cfg),
+				typed("Proxy", func(pa *ingress.ParsedAnnotations, val interface{}) bool {
+					v, ok := val.(*proxy.Config)
+					pa.Proxy = v
+					return ok
+				}),
+			},
+			"RateLimit": {
+				ratelimit.NewParser(),
+				typed("RateLimit", func(pa *ingress.ParsedAnnotations, val interface{}) bool {
+					v, ok := val.(*ratelimit.Config)
+					pa.RateLimit = v
+					return ok
+				}),
+			},
+			"Redirect": {
+				rewrite.NewParser(cfg),
+				typed("Redirect", func(pa *ingress.ParsedAnnotations, val interface{}) bool {
+					v, ok := val.(*rewrite.Config)
+					pa.Redirect = v
+					return ok
+				}),
+			},
+			"SecureUpstream": {
+				secureupstream.NewParser(),
+				typed("SecureUpstream", func(pa *ingress.ParsedAnnotations, val interface{}) bool {
+					v, ok := val.(bool)
+					pa.SecureUpstream = v
+					return ok
+				}),
+			},
+			"SessionAffinity": {
+				sessionaffinity.NewParser(),
+				typed("SessionAffinity", func(pa *ingress.ParsedAnnotations, val interface{}) bool {
+					v, ok := val.(*sessionaffinity.AffinityConfig)
+					pa.SessionAffinity = v
+					return ok
+				}),
+			},
+			"SSLPassthrough": {
+				sslpassthrough.NewParser(),
+				typed("SSLPassthrough", func(pa *ingress.ParsedAnnotations, val interface{}) bool {
+					v, ok := val.(bool)
+					pa.SSLPassthrough = v
+					return ok
+				}),
+			},
+			"ConfigurationSnippet": {
+				snippet.NewParser(),
+				typed("ConfigurationSnippet", func(pa *ingress.ParsedAnnotations, val interface{}) bool {
+					v, ok := val.(string)
+					pa.ConfigurationSnippet = v
+					return ok
+				}),
+			},
 		},
 	}
 }
 
-func (e *annotationExtractor) Extract(ing *extensions.Ingress) map[string]interface{} {
-	anns := make(map[string]interface{}, 0)
-	for name, annotationParser := range e.annotations {
-		val, err := annotationParser.Parse(ing)
+// Extract runs every registered parser against ing and returns a typed
+// ParsedAnnotations. A parser returning a value of the wrong type for its
+// field is itself treated as a denial: it is a bug in the parser or its
+// registry entry, so it is caught here instead of panicking the first
+// time some downstream code reads the field.
+func (e *annotationExtractor) Extract(ing *extensions.Ingress) *ingress.ParsedAnnotations {
+	pa := &ingress.ParsedAnnotations{}
+
+	for name, entry := range e.annotations {
+		val, err := entry.parser.Parse(ing)
 		glog.V(5).Infof("annotation %v in Ingress %v/%v: %v", name, ing.GetNamespace(), ing.GetName(), val)
 		if err != nil {
 			if errors.IsMissingAnnotations(err) {
 				continue
 			}
 
-			_, alreadyDenied := anns[DeniedKeyName]
-			if !alreadyDenied {
-				anns[DeniedKeyName] = err
+			if pa.Denied == nil {
+				pa.Denied = err
 				glog.Errorf("error reading %v annotation in Ingress %v/%v: %v", name, ing.GetNamespace(), ing.GetName(), err)
-				continue
+			} else {
+				glog.V(5).Infof("error reading %v annotation in Ingress %v/%v: %v", name, ing.GetNamespace(), ing.GetName(), err)
 			}
+			continue
+		}
 
-			glog.V(5).Infof("error reading %v annotation in Ingress %v/%v: %v", name, ing.GetNamespace(), ing.GetName(), err)
+		if val == nil {
+			continue
 		}
 
-		if val != nil {
-			anns[name] = val
+		if assignErr := entry.assign(pa, val); assignErr != nil && pa.Denied == nil {
+			glog.Errorf("internal error parsing %v annotation in Ingress %v/%v: %v", name, ing.GetNamespace(), ing.GetName(), assignErr)
+			pa.Denied = assignErr
 		}
 	}
 
-	return anns
+	return pa
 }
 
-const (
-	secureUpstream  = "SecureUpstream"
-	healthCheck     = "HealthCheck"
-	sslPassthrough  = "SSLPassthrough"
-	sessionAffinity = "SessionAffinity"
-)
+// SecureUpstream is kept only as a compatibility shim around BackendProtocol:
+// ingress.kubernetes.io/secure-upstream now just implies Protocol=HTTPS.
+// Prefer BackendProtocol, which also covers gRPC/H2C/FCGI/AJP upstreams.
+func (e *annotationExtractor) SecureUpstream(pa *ingress.ParsedAnnotations) bool {
+	proto, err := e.BackendProtocol(pa)
+	return err == nil && proto == backendprotocol.HTTPS
+}
+
+// BackendProtocol returns the protocol the backend behind pa expects
+// traffic in. ingress.kubernetes.io/secure-upstream is honoured as a
+// deprecated alias for Protocol=HTTPS when backend-protocol is not set;
+// when both annotations are present they must agree, otherwise the
+// Ingress is denied.
+func (e *annotationExtractor) BackendProtocol(pa *ingress.ParsedAnnotations) (backendprotocol.Protocol, error) {
+	if pa.BackendProtocol == "" {
+		if pa.SecureUpstream {
+			return backendprotocol.HTTPS, nil
+		}
+		return backendprotocol.HTTP, nil
+	}
 
-func (e *annotationExtractor) SecureUpstream(ing *extensions.Ingress) bool {
-	val, _ := e.annotations[secureUpstream].Parse(ing)
-	return val.(bool)
+	if pa.SecureUpstream && pa.BackendProtocol != backendprotocol.HTTPS {
+		return "", errors.NewLocationDenied(fmt.Sprintf(
+			"conflicting backend protocol: secure-upstream=true but backend-protocol=%v", pa.BackendProtocol))
+	}
+
+	return pa.BackendProtocol, nil
+}
+
+func (e *annotationExtractor) HealthCheck(pa *ingress.ParsedAnnotations) *healthcheck.Upstream {
+	return pa.HealthCheck
+}
+
+func (e *annotationExtractor) SSLPassthrough(pa *ingress.ParsedAnnotations) bool {
+	return pa.SSLPassthrough
+}
+
+func (e *annotationExtractor) SessionAffinity(pa *ingress.ParsedAnnotations) *sessionaffinity.AffinityConfig {
+	return pa.SessionAffinity
 }
 
-func (e *annotationExtractor) HealthCheck(ing *extensions.Ingress) *healthcheck.Upstream {
-	val, _ := e.annotations[healthCheck].Parse(ing)
-	return val.(*healthcheck.Upstream)
+// AnnotationExtractor is the subset of annotationExtractor that can safely
+// be handed to subsystems outside this package (e.g. the admission
+// webhook), without exposing the internal parser registry.
+type AnnotationExtractor interface {
+	Validate(ing *extensions.Ingress) error
 }
 
-func (e *annotationExtractor) SSLPassthrough(ing *extensions.Ingress) bool {
-	val, _ := e.annotations[sslPassthrough].Parse(ing)
-	return val.(bool)
+// NewAnnotationExtractor builds an AnnotationExtractor backed by the same
+// parser registry used by the controller itself, so annotations are
+// validated identically whether they are rejected at admission time or
+// merely logged during a sync.
+func NewAnnotationExtractor(cfg extractorConfig) AnnotationExtractor {
+	e := newAnnotationExtractor(cfg)
+	return &e
 }
 
-func (e *annotationExtractor) SessionAffinity(ing *extensions.Ingress) *sessionaffinity.AffinityConfig {
-	val, _ := e.annotations[sessionAffinity].Parse(ing)
-	return val.(*sessionaffinity.AffinityConfig)
+// Validate runs every registered parser against ing and returns the first
+// error that is not just a missing annotation. It is used by the admission
+// webhook to reject Ingresses with invalid annotations before they reach
+// the API server.
+func (e *annotationExtractor) Validate(ing *extensions.Ingress) error {
+	for name, entry := range e.annotations {
+		_, err := entry.parser.Parse(ing)
+		if err == nil {
+			continue
+		}
+
+		if errors.IsMissingAnnotations(err) {
+			continue
+		}
+
+		glog.V(3).Infof("admission rejected ingress %v/%v: annotation %v: %v", ing.Namespace, ing.Name, name, err)
+		return err
+	}
+
+	return nil
 }
 
 func (e *annotationExtractor) ContainsCertificateAuth(ing *extensions.Ingress) bool {
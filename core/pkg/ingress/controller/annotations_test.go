@@ -0,0 +1,125 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"testing"
+
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	extensions "k8s.io/client-go/pkg/apis/extensions/v1beta1"
+
+	"k8s.io/ingressl4/core/pkg/ingress"
+	"k8s.io/ingressl4/core/pkg/ingress/annotations/backendprotocol"
+	"k8s.io/ingressl4/core/pkg/ingress/annotations/healthcheck"
+	"k8s.io/ingressl4/core/pkg/ingress/errors"
+)
+
+// wrongTypeParser always succeeds but returns a value of the wrong type
+// for the field it is registered against, simulating a typo in a
+// registry entry (e.g. copy-pasting the wrong assign closure).
+type wrongTypeParser struct{}
+
+func (wrongTypeParser) Parse(ing *extensions.Ingress) (interface{}, error) {
+	return "not-a-healthcheck-upstream", nil
+}
+
+func TestExtractCatchesWrongParserType(t *testing.T) {
+	e := annotationExtractor{
+		annotations: map[string]annotationEntry{
+			"HealthCheck": {
+				parser: wrongTypeParser{},
+				assign: typed("HealthCheck", func(pa *ingress.ParsedAnnotations, val interface{}) bool {
+					v, ok := val.(*healthcheck.Upstream)
+					pa.HealthCheck = v
+					return ok
+				}),
+			},
+		},
+	}
+
+	ing := &extensions.Ingress{ObjectMeta: meta_v1.ObjectMeta{Namespace: "default", Name: "broken-registry-entry"}}
+
+	pa := e.Extract(ing)
+
+	if pa.Denied == nil {
+		t.Fatalf("expected Extract to deny the ingress when a parser returns the wrong type, got nil")
+	}
+
+	if pa.HealthCheck != nil {
+		t.Fatalf("expected HealthCheck to stay nil, got %+v", pa.HealthCheck)
+	}
+}
+
+func TestBackendProtocol(t *testing.T) {
+	tests := []struct {
+		name     string
+		pa       *ingress.ParsedAnnotations
+		expected backendprotocol.Protocol
+		denied   bool
+	}{
+		{
+			name:     "neither annotation set defaults to HTTP",
+			pa:       &ingress.ParsedAnnotations{},
+			expected: backendprotocol.HTTP,
+		},
+		{
+			name:     "secure-upstream alone implies HTTPS",
+			pa:       &ingress.ParsedAnnotations{SecureUpstream: true},
+			expected: backendprotocol.HTTPS,
+		},
+		{
+			name:     "backend-protocol alone is honoured as-is",
+			pa:       &ingress.ParsedAnnotations{BackendProtocol: backendprotocol.GRPC},
+			expected: backendprotocol.GRPC,
+		},
+		{
+			name:     "secure-upstream and backend-protocol agreeing on HTTPS",
+			pa:       &ingress.ParsedAnnotations{SecureUpstream: true, BackendProtocol: backendprotocol.HTTPS},
+			expected: backendprotocol.HTTPS,
+		},
+		{
+			name:   "secure-upstream conflicting with a non-HTTPS backend-protocol is denied",
+			pa:     &ingress.ParsedAnnotations{SecureUpstream: true, BackendProtocol: backendprotocol.GRPC},
+			denied: true,
+		},
+	}
+
+	e := annotationExtractor{}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			proto, err := e.BackendProtocol(tt.pa)
+
+			if tt.denied {
+				if err == nil {
+					t.Fatalf("expected a conflicting backend protocol to be denied")
+				}
+				if !errors.IsLocationDenied(err) {
+					t.Fatalf("expected a location denied error, got %v", err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if proto != tt.expected {
+				t.Errorf("expected protocol %v, got %v", tt.expected, proto)
+			}
+		})
+	}
+}
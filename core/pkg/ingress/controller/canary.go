@@ -0,0 +1,99 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"hash/fnv"
+
+	"k8s.io/ingressl4/core/pkg/ingress"
+	"k8s.io/ingressl4/core/pkg/ingress/annotations/canary"
+)
+
+// mergeCanaryBackends collapses backends produced by a canary Ingress into
+// the backend of the stable Ingress they share a host+path with: the
+// stable backend keeps serving as before and gains an AlternativeBackends
+// entry pointing at the canary backend, so templates can split traffic
+// between the two without the operator duplicating the whole rule.
+// stableByCanaryName maps a canary backend's name to the name of the
+// stable backend that owns the same host+path, as resolved from the
+// shared informer cache while building backends.
+func mergeCanaryBackends(backends []*ingress.Backend, canaries map[string]*canary.Config, stableByCanaryName map[string]string) []*ingress.Backend {
+	merged := make([]*ingress.Backend, 0, len(backends))
+	byName := make(map[string]*ingress.Backend, len(backends))
+
+	for _, b := range backends {
+		byName[b.Name] = b
+	}
+
+	for _, b := range backends {
+		cfg, isCanary := canaries[b.Name]
+		if !isCanary || !cfg.Enabled {
+			merged = append(merged, b)
+			continue
+		}
+
+		stableName, ok := stableByCanaryName[b.Name]
+		if !ok {
+			// No stable counterpart shares this host+path; treat it
+			// as a regular backend rather than dropping the traffic.
+			merged = append(merged, b)
+			continue
+		}
+
+		stable, ok := byName[stableName]
+		if !ok {
+			// The cache named a stable backend that is no longer part
+			// of this sync; fall back to serving the canary backend
+			// standalone instead of silently dropping its traffic.
+			merged = append(merged, b)
+			continue
+		}
+
+		stable.AlternativeBackends = append(stable.AlternativeBackends, b.Name)
+	}
+
+	return merged
+}
+
+// routeToCanary decides whether a single request should be sent to the
+// canary backend described by cfg. A header or cookie match always wins;
+// otherwise remoteAddr+cookieAffinity is hashed to a uint32 and taken
+// mod 100, so repeated requests from the same client land on the same
+// side of the split instead of flapping between stable and canary.
+func routeToCanary(remoteAddr, cookieAffinity, headerValue, cookieValue string, cfg *canary.Config) bool {
+	if cfg == nil || !cfg.Enabled {
+		return false
+	}
+
+	if cfg.Header != "" && headerValue != "" && headerValue == cfg.HeaderValue {
+		return true
+	}
+
+	if cfg.Cookie != "" {
+		switch cookieValue {
+		case "always":
+			return true
+		case "never":
+			return false
+		}
+	}
+
+	h := fnv.New32a()
+	h.Write([]byte(remoteAddr + cookieAffinity))
+
+	return int(h.Sum32()%100) < cfg.Weight
+}
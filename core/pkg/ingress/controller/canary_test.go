@@ -0,0 +1,127 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"testing"
+
+	"k8s.io/ingressl4/core/pkg/ingress"
+	"k8s.io/ingressl4/core/pkg/ingress/annotations/canary"
+)
+
+func TestMergeCanaryBackendsAttachesAlternative(t *testing.T) {
+	backends := []*ingress.Backend{
+		{Name: "default-app-stable"},
+		{Name: "default-app-canary"},
+	}
+	canaries := map[string]*canary.Config{
+		"default-app-canary": {Enabled: true, Weight: 10},
+	}
+	stableByCanaryName := map[string]string{
+		"default-app-canary": "default-app-stable",
+	}
+
+	merged := mergeCanaryBackends(backends, canaries, stableByCanaryName)
+
+	if len(merged) != 1 {
+		t.Fatalf("expected the canary backend to be folded into the stable one, got %d backends", len(merged))
+	}
+
+	if got := merged[0].AlternativeBackends; len(got) != 1 || got[0] != "default-app-canary" {
+		t.Fatalf("expected stable backend to list the canary as an alternative, got %+v", got)
+	}
+}
+
+func TestMergeCanaryBackendsNoStableCounterpart(t *testing.T) {
+	backends := []*ingress.Backend{{Name: "default-app-canary"}}
+	canaries := map[string]*canary.Config{
+		"default-app-canary": {Enabled: true, Weight: 10},
+	}
+
+	merged := mergeCanaryBackends(backends, canaries, map[string]string{})
+
+	if len(merged) != 1 || merged[0].Name != "default-app-canary" {
+		t.Fatalf("expected canary backend to be kept standalone, got %+v", merged)
+	}
+}
+
+func TestMergeCanaryBackendsStaleStableReference(t *testing.T) {
+	backends := []*ingress.Backend{{Name: "default-app-canary"}}
+	canaries := map[string]*canary.Config{
+		"default-app-canary": {Enabled: true, Weight: 10},
+	}
+	// The cache names a stable backend that no longer exists in this
+	// sync's backend list.
+	stableByCanaryName := map[string]string{
+		"default-app-canary": "default-app-stable-removed",
+	}
+
+	merged := mergeCanaryBackends(backends, canaries, stableByCanaryName)
+
+	if len(merged) != 1 || merged[0].Name != "default-app-canary" {
+		t.Fatalf("expected canary backend to degrade to standalone instead of vanishing, got %+v", merged)
+	}
+}
+
+func TestRouteToCanaryDisabled(t *testing.T) {
+	if routeToCanary("1.2.3.4", "", "", "", &canary.Config{Enabled: false, Weight: 100}) {
+		t.Fatalf("expected disabled canary to never receive traffic")
+	}
+}
+
+func TestRouteToCanaryHeaderMatch(t *testing.T) {
+	cfg := &canary.Config{Enabled: true, Weight: 0, Header: "X-Canary", HeaderValue: "always"}
+
+	if !routeToCanary("1.2.3.4", "", "always", "", cfg) {
+		t.Fatalf("expected a matching header to force canary routing even with weight 0")
+	}
+}
+
+func TestRouteToCanaryAbsentHeaderDoesNotForceRouting(t *testing.T) {
+	// canary-by-header set without canary-by-header-value: HeaderValue
+	// defaults to "". A request that never sent the header at all must
+	// not be treated as a match just because both sides are empty.
+	cfg := &canary.Config{Enabled: true, Weight: 0, Header: "X-Canary", HeaderValue: ""}
+
+	if routeToCanary("1.2.3.4", "", "", "", cfg) {
+		t.Fatalf("expected an absent header to fall through to the weight split, not force canary")
+	}
+}
+
+func TestRouteToCanaryCookieAlwaysNever(t *testing.T) {
+	cfg := &canary.Config{Enabled: true, Weight: 0, Cookie: "canary"}
+
+	if !routeToCanary("1.2.3.4", "", "", "always", cfg) {
+		t.Fatalf("expected cookie value 'always' to force canary routing")
+	}
+
+	cfg = &canary.Config{Enabled: true, Weight: 100, Cookie: "canary"}
+	if routeToCanary("1.2.3.4", "", "", "never", cfg) {
+		t.Fatalf("expected cookie value 'never' to force stable routing")
+	}
+}
+
+func TestRouteToCanaryWeightSplitIsDeterministic(t *testing.T) {
+	cfg := &canary.Config{Enabled: true, Weight: 50}
+
+	first := routeToCanary("10.0.0.1", "affinity-a", "", "", cfg)
+	second := routeToCanary("10.0.0.1", "affinity-a", "", "", cfg)
+
+	if first != second {
+		t.Fatalf("expected the same remoteAddr+cookieAffinity to always land on the same side of the split")
+	}
+}
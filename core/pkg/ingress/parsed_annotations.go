@@ -0,0 +1,64 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ingress
+
+import (
+	"k8s.io/ingressl4/core/pkg/ingress/annotations/auth"
+	"k8s.io/ingressl4/core/pkg/ingress/annotations/authreq"
+	"k8s.io/ingressl4/core/pkg/ingress/annotations/authtls"
+	"k8s.io/ingressl4/core/pkg/ingress/annotations/backendprotocol"
+	"k8s.io/ingressl4/core/pkg/ingress/annotations/canary"
+	"k8s.io/ingressl4/core/pkg/ingress/annotations/cors"
+	"k8s.io/ingressl4/core/pkg/ingress/annotations/healthcheck"
+	"k8s.io/ingressl4/core/pkg/ingress/annotations/ipwhitelist"
+	"k8s.io/ingressl4/core/pkg/ingress/annotations/proxy"
+	"k8s.io/ingressl4/core/pkg/ingress/annotations/ratelimit"
+	"k8s.io/ingressl4/core/pkg/ingress/annotations/rewrite"
+	"k8s.io/ingressl4/core/pkg/ingress/annotations/sessionaffinity"
+)
+
+// ParsedAnnotations is the typed result of running every registered
+// annotation parser against an Ingress. It replaces the old
+// map[string]interface{} returned by annotationExtractor.Extract: a
+// caller reading, say, ParsedAnnotations.HealthCheck gets a field of the
+// right type directly, instead of having to do a val.(*healthcheck.Upstream)
+// assertion that only panics the first time that code path runs.
+type ParsedAnnotations struct {
+	BasicDigestAuth      *auth.Config
+	ExternalAuth         *authreq.Config
+	CertificateAuth      *authtls.Config
+	BackendProtocol      backendprotocol.Protocol
+	Canary               *canary.Config
+	EnableCORS           *cors.Config
+	HealthCheck          *healthcheck.Upstream
+	Whitelist            *ipwhitelist.Config
+	UsePortInRedirects   bool
+	Proxy                *proxy.Config
+	RateLimit            *ratelimit.Config
+	Redirect             *rewrite.Config
+	SecureUpstream       bool
+	SessionAffinity      *sessionaffinity.AffinityConfig
+	SSLPassthrough       bool
+	ConfigurationSnippet string
+
+	// Denied is the first parser error that was not just a missing
+	// annotation, or an internal error raised by the registry itself
+	// when a parser returns a value of the wrong type for its field.
+	// A non-nil Denied means the Ingress must not be synced until it
+	// is fixed.
+	Denied error
+}
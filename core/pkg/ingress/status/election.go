@@ -0,0 +1,107 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package status
+
+import (
+	"time"
+
+	"github.com/golang/glog"
+
+	"k8s.io/client-go/kubernetes"
+
+	"k8s.io/ingressl4/core/pkg/ingress/status/leaderelection/resourcelock"
+)
+
+// Election periodically reads a leader election lock and notifies a
+// callback whenever the observed holder changes.
+type Election struct {
+	lock     resourcelock.Interface
+	ttl      time.Duration
+	callback func(leader string)
+
+	leader string
+}
+
+// NewElection starts a leader-election loop backed by lockType. The lock
+// used to be hard-wired to an Endpoints object; lockType now lets callers
+// pick ConfigMaps, Leases (the mechanism recommended since Kubernetes
+// 1.14) or an external store for controllers that run outside of a
+// cluster. store is only consulted when lockType is
+// resourcelock.LockTypeExternal and may be nil otherwise.
+func NewElection(name, id, namespace string, lockType resourcelock.LockType, store resourcelock.LeaderStore, ttl time.Duration, callback func(leader string), kubeClient kubernetes.Interface) (*Election, error) {
+	lock, err := resourcelock.New(lockType, namespace, name, kubeClient, store, resourcelock.Config{Identity: id})
+	if err != nil {
+		return nil, err
+	}
+
+	record, err := lock.Get()
+	if err != nil {
+		return nil, err
+	}
+
+	e := &Election{
+		lock:     lock,
+		ttl:      ttl,
+		callback: callback,
+		leader:   record.HolderIdentity,
+	}
+
+	go e.run()
+
+	return e, nil
+}
+
+func (e *Election) run() {
+	for {
+		time.Sleep(e.ttl)
+
+		record, err := e.lock.Get()
+		if err != nil {
+			glog.Errorf("error reading leader election lock %v: %v", e.lock.Describe(), err)
+			continue
+		}
+
+		if record.HolderIdentity == e.leader {
+			continue
+		}
+
+		e.leader = record.HolderIdentity
+		if e.callback != nil {
+			e.callback(e.leader)
+		}
+	}
+}
+
+// getCurrentLeader returns the identity currently recorded in the lock
+// identified by name/namespace/lockType, and the lock itself so callers
+// that already have one do not need to build it twice. An empty identity
+// with a nil error means no leader has been recorded yet, which is not an
+// error condition. store is only used when lockType is
+// resourcelock.LockTypeExternal.
+func getCurrentLeader(name, namespace string, lockType resourcelock.LockType, kubeClient kubernetes.Interface, store resourcelock.LeaderStore) (string, resourcelock.Interface, error) {
+	lock, err := resourcelock.New(lockType, namespace, name, kubeClient, store, resourcelock.Config{})
+	if err != nil {
+		return "", nil, err
+	}
+
+	record, err := lock.Get()
+	if err != nil {
+		return "", nil, err
+	}
+
+	return record.HolderIdentity, lock, nil
+}
@@ -18,10 +18,13 @@ package status
 
 import (
 	"encoding/json"
+	"fmt"
 	"testing"
 	"time"
 
+	coordinationv1 "k8s.io/api/coordination/v1"
 	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/kubernetes/fake"
 	"k8s.io/client-go/pkg/api"
 	api_v1 "k8s.io/client-go/pkg/api/v1"
@@ -29,66 +32,172 @@ import (
 	"k8s.io/ingressl4/core/pkg/ingress/status/leaderelection/resourcelock"
 )
 
-func TestGetCurrentLeaderLeaderExist(t *testing.T) {
-	fkER := resourcelock.LeaderElectionRecord{
-		HolderIdentity:       "currentLeader",
+const (
+	testLockName = "ingress-controller-test"
+)
+
+// fakeLeaderStore is an in-memory resourcelock.LeaderStore used to
+// exercise the LockTypeExternal backend without a real etcd/Consul.
+type fakeLeaderStore struct {
+	values map[string]string
+}
+
+func newFakeLeaderStore() *fakeLeaderStore {
+	return &fakeLeaderStore{values: map[string]string{}}
+}
+
+func (s *fakeLeaderStore) Get(key string) (string, error) {
+	return s.values[key], nil
+}
+
+func (s *fakeLeaderStore) CAS(key, oldValue, newValue string, ttl time.Duration) (bool, error) {
+	if s.values[key] != oldValue {
+		return false, nil
+	}
+	s.values[key] = newValue
+	return true, nil
+}
+
+func leaderRecord(identity string) resourcelock.LeaderElectionRecord {
+	return resourcelock.LeaderElectionRecord{
+		HolderIdentity:       identity,
 		LeaseDurationSeconds: 30,
 		AcquireTime:          meta_v1.NewTime(time.Now()),
 		RenewTime:            meta_v1.NewTime(time.Now()),
 		LeaderTransitions:    3,
 	}
-	leaderInfo, _ := json.Marshal(fkER)
-	fkEndpoints := api_v1.Endpoints{
-		ObjectMeta: meta_v1.ObjectMeta{
-			Name:      "ingress-controller-test",
-			Namespace: api.NamespaceSystem,
-			Annotations: map[string]string{
-				resourcelock.LeaderElectionRecordAnnotationKey: string(leaderInfo),
-			},
-		},
-	}
-	fk := fake.NewSimpleClientset(&api_v1.EndpointsList{Items: []api_v1.Endpoints{fkEndpoints}})
-	identity, endpoints, err := getCurrentLeader("ingress-controller-test", api.NamespaceSystem, fk)
-	if err != nil {
-		t.Fatalf("expected identitiy and endpoints but returned error %s", err)
+}
+
+// seedClient returns a fake clientset with testLockName already present
+// for lockType, annotated/populated with a leader record when identity is
+// non-empty.
+func seedClient(t *testing.T, lockType resourcelock.LockType, identity string) kubernetes.Interface {
+	switch lockType {
+	case resourcelock.LockTypeEndpoints:
+		ep := api_v1.Endpoints{
+			ObjectMeta: meta_v1.ObjectMeta{Name: testLockName, Namespace: api.NamespaceSystem},
+		}
+		if identity != "" {
+			raw, _ := json.Marshal(leaderRecord(identity))
+			ep.Annotations = map[string]string{resourcelock.LeaderElectionRecordAnnotationKey: string(raw)}
+		}
+		return fake.NewSimpleClientset(&api_v1.EndpointsList{Items: []api_v1.Endpoints{ep}})
+	case resourcelock.LockTypeConfigMaps:
+		cm := api_v1.ConfigMap{
+			ObjectMeta: meta_v1.ObjectMeta{Name: testLockName, Namespace: api.NamespaceSystem},
+		}
+		if identity != "" {
+			raw, _ := json.Marshal(leaderRecord(identity))
+			cm.Annotations = map[string]string{resourcelock.LeaderElectionRecordAnnotationKey: string(raw)}
+		}
+		return fake.NewSimpleClientset(&api_v1.ConfigMapList{Items: []api_v1.ConfigMap{cm}})
+	case resourcelock.LockTypeLeases:
+		lease := coordinationv1.Lease{
+			ObjectMeta: meta_v1.ObjectMeta{Name: testLockName, Namespace: api.NamespaceSystem},
+		}
+		if identity != "" {
+			holder := identity
+			lease.Spec.HolderIdentity = &holder
+		}
+		return fake.NewSimpleClientset(&coordinationv1.LeaseList{Items: []coordinationv1.Lease{lease}})
 	}
 
-	if endpoints == nil {
-		t.Fatalf("returned nil but expected an endpoints")
+	t.Fatalf("seedClient does not support lock type %v", lockType)
+	return nil
+}
+
+func TestGetCurrentLeaderLeaderExist(t *testing.T) {
+	backends := []resourcelock.LockType{
+		resourcelock.LockTypeEndpoints,
+		resourcelock.LockTypeConfigMaps,
+		resourcelock.LockTypeLeases,
 	}
 
-	if identity != "currentLeader" {
-		t.Fatalf("returned %v but expected %v", identity, "currentLeader")
+	for _, lockType := range backends {
+		t.Run(string(lockType), func(t *testing.T) {
+			fk := seedClient(t, lockType, "currentLeader")
+
+			identity, lock, err := getCurrentLeader(testLockName, api.NamespaceSystem, lockType, fk, nil)
+			if err != nil {
+				t.Fatalf("expected identity and lock but returned error %s", err)
+			}
+
+			if lock == nil {
+				t.Fatalf("returned nil but expected a lock")
+			}
+
+			if identity != "currentLeader" {
+				t.Fatalf("returned %v but expected %v", identity, "currentLeader")
+			}
+		})
 	}
+
+	t.Run(string(resourcelock.LockTypeExternal), func(t *testing.T) {
+		store := newFakeLeaderStore()
+		raw, _ := json.Marshal(leaderRecord("currentLeader"))
+		store.values[fmt.Sprintf("%v/%v", api.NamespaceSystem, testLockName)] = string(raw)
+
+		identity, lock, err := getCurrentLeader(testLockName, api.NamespaceSystem, resourcelock.LockTypeExternal, nil, store)
+		if err != nil {
+			t.Fatalf("expected identity and lock but returned error %s", err)
+		}
+
+		if lock == nil {
+			t.Fatalf("returned nil but expected a lock")
+		}
+
+		if identity != "currentLeader" {
+			t.Fatalf("returned %v but expected %v", identity, "currentLeader")
+		}
+	})
 }
 
 func TestGetCurrentLeaderLeaderNotExist(t *testing.T) {
-	fkEndpoints := api_v1.Endpoints{
-		ObjectMeta: meta_v1.ObjectMeta{
-			Name:        "ingress-controller-test",
-			Namespace:   api.NamespaceSystem,
-			Annotations: map[string]string{},
-		},
-	}
-	fk := fake.NewSimpleClientset(&api_v1.EndpointsList{Items: []api_v1.Endpoints{fkEndpoints}})
-	identity, endpoints, err := getCurrentLeader("ingress-controller-test", api.NamespaceSystem, fk)
-	if err != nil {
-		t.Fatalf("unexpeted error: %v", err)
+	backends := []resourcelock.LockType{
+		resourcelock.LockTypeEndpoints,
+		resourcelock.LockTypeConfigMaps,
+		resourcelock.LockTypeLeases,
 	}
 
-	if endpoints == nil {
-		t.Fatalf("returned nil but expected an endpoints")
-	}
+	for _, lockType := range backends {
+		t.Run(string(lockType), func(t *testing.T) {
+			fk := seedClient(t, lockType, "")
+
+			identity, lock, err := getCurrentLeader(testLockName, api.NamespaceSystem, lockType, fk, nil)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
 
-	if identity != "" {
-		t.Fatalf("returned %s but expected %s", identity, "")
+			if lock == nil {
+				t.Fatalf("returned nil but expected a lock")
+			}
+
+			if identity != "" {
+				t.Fatalf("returned %s but expected %s", identity, "")
+			}
+		})
 	}
+
+	t.Run(string(resourcelock.LockTypeExternal), func(t *testing.T) {
+		identity, lock, err := getCurrentLeader(testLockName, api.NamespaceSystem, resourcelock.LockTypeExternal, nil, newFakeLeaderStore())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if lock == nil {
+			t.Fatalf("returned nil but expected a lock")
+		}
+
+		if identity != "" {
+			t.Fatalf("returned %s but expected %s", identity, "")
+		}
+	})
 }
 
 func TestGetCurrentLeaderAnnotationError(t *testing.T) {
 	fkEndpoints := api_v1.Endpoints{
 		ObjectMeta: meta_v1.ObjectMeta{
-			Name:      "ingress-controller-test",
+			Name:      testLockName,
 			Namespace: api.NamespaceSystem,
 			Annotations: map[string]string{
 				resourcelock.LeaderElectionRecordAnnotationKey: "just-test-error-leader-annotation",
@@ -96,37 +205,54 @@ func TestGetCurrentLeaderAnnotationError(t *testing.T) {
 		},
 	}
 	fk := fake.NewSimpleClientset(&api_v1.EndpointsList{Items: []api_v1.Endpoints{fkEndpoints}})
-	_, _, err := getCurrentLeader("ingress-controller-test", api.NamespaceSystem, fk)
+
+	_, _, err := getCurrentLeader(testLockName, api.NamespaceSystem, resourcelock.LockTypeEndpoints, fk, nil)
 	if err == nil {
 		t.Errorf("expected error")
 	}
 }
 
+func TestGetCurrentLeaderInvalidLockType(t *testing.T) {
+	_, _, err := getCurrentLeader(testLockName, api.NamespaceSystem, resourcelock.LockType("bogus"), fake.NewSimpleClientset(), nil)
+	if err == nil {
+		t.Errorf("expected error for invalid lock type")
+	}
+}
+
 func TestNewElection(t *testing.T) {
-	fk := fake.NewSimpleClientset(&api_v1.EndpointsList{Items: []api_v1.Endpoints{
-		{
-			ObjectMeta: meta_v1.ObjectMeta{
-				Name:      "ingress-controller-test",
-				Namespace: api.NamespaceSystem,
-			},
-		},
-		{
-			ObjectMeta: meta_v1.ObjectMeta{
-				Name:      "ingress-controller-test-020",
-				Namespace: api.NamespaceSystem,
-			},
-		},
-	}})
-
-	ne, err := NewElection("ingress-controller-test", "startLeader", api.NamespaceSystem, 4*time.Second, func(leader string) {
-		// do nothing
-		go t.Logf("execute callback fun, leader is: %s", leader)
-	}, fk)
-	if err != nil {
-		t.Fatalf("unexpected error %v", err)
+	backends := []resourcelock.LockType{
+		resourcelock.LockTypeEndpoints,
+		resourcelock.LockTypeConfigMaps,
+		resourcelock.LockTypeLeases,
 	}
 
-	if ne == nil {
-		t.Fatalf("unexpected nil")
+	for _, lockType := range backends {
+		t.Run(string(lockType), func(t *testing.T) {
+			fk := seedClient(t, lockType, "")
+
+			ne, err := NewElection(testLockName, "startLeader", api.NamespaceSystem, lockType, nil, 4*time.Second, func(leader string) {
+				go t.Logf("execute callback fun, leader is: %s", leader)
+			}, fk)
+			if err != nil {
+				t.Fatalf("unexpected error %v", err)
+			}
+
+			if ne == nil {
+				t.Fatalf("unexpected nil")
+			}
+		})
 	}
+
+	t.Run(string(resourcelock.LockTypeExternal), func(t *testing.T) {
+		ne, err := NewElection(testLockName, "startLeader", api.NamespaceSystem, resourcelock.LockTypeExternal, newFakeLeaderStore(), 4*time.Second, func(leader string) {
+			go t.Logf("execute callback fun, leader is: %s", leader)
+		}, nil)
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+
+		if ne == nil {
+			t.Fatalf("unexpected nil")
+		}
+	})
 }
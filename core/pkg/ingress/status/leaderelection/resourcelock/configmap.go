@@ -0,0 +1,102 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resourcelock
+
+import (
+	"encoding/json"
+	"fmt"
+
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	api_v1 "k8s.io/client-go/pkg/api/v1"
+)
+
+// ConfigMapLock stores the LeaderElectionRecord as a JSON annotation on a
+// ConfigMap object, for clusters that would rather not grant controllers
+// write access to Endpoints.
+type ConfigMapLock struct {
+	ConfigMapMeta meta_v1.ObjectMeta
+	Client        kubernetes.Interface
+	LockConfig    Config
+
+	cm *api_v1.ConfigMap
+}
+
+// Get implements Interface.
+func (cml *ConfigMapLock) Get() (*LeaderElectionRecord, error) {
+	cm, err := cml.Client.CoreV1().ConfigMaps(cml.ConfigMapMeta.Namespace).Get(cml.ConfigMapMeta.Name, meta_v1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	cml.cm = cm
+
+	record := &LeaderElectionRecord{}
+	raw, found := cm.Annotations[LeaderElectionRecordAnnotationKey]
+	if !found {
+		return record, nil
+	}
+
+	if err := json.Unmarshal([]byte(raw), record); err != nil {
+		return nil, err
+	}
+
+	return record, nil
+}
+
+// Create implements Interface.
+func (cml *ConfigMapLock) Create(ler LeaderElectionRecord) error {
+	return cml.update(ler)
+}
+
+// Update implements Interface.
+func (cml *ConfigMapLock) Update(ler LeaderElectionRecord) error {
+	return cml.update(ler)
+}
+
+func (cml *ConfigMapLock) update(ler LeaderElectionRecord) error {
+	if cml.cm == nil {
+		return fmt.Errorf("configmap lock %v not initialized, call Get() first", cml.Describe())
+	}
+
+	raw, err := json.Marshal(ler)
+	if err != nil {
+		return err
+	}
+
+	if cml.cm.Annotations == nil {
+		cml.cm.Annotations = make(map[string]string)
+	}
+	cml.cm.Annotations[LeaderElectionRecordAnnotationKey] = string(raw)
+
+	updated, err := cml.Client.CoreV1().ConfigMaps(cml.ConfigMapMeta.Namespace).Update(cml.cm)
+	if err != nil {
+		return err
+	}
+	cml.cm = updated
+
+	return nil
+}
+
+// Identity implements Interface.
+func (cml *ConfigMapLock) Identity() string {
+	return cml.LockConfig.Identity
+}
+
+// Describe implements Interface.
+func (cml *ConfigMapLock) Describe() string {
+	return fmt.Sprintf("%v/%v (configmap)", cml.ConfigMapMeta.Namespace, cml.ConfigMapMeta.Name)
+}
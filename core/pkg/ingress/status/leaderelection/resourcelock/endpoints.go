@@ -0,0 +1,101 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resourcelock
+
+import (
+	"encoding/json"
+	"fmt"
+
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	api_v1 "k8s.io/client-go/pkg/api/v1"
+)
+
+// EndpointsLock stores the LeaderElectionRecord as a JSON annotation on an
+// Endpoints object.
+type EndpointsLock struct {
+	EndpointsMeta meta_v1.ObjectMeta
+	Client        kubernetes.Interface
+	LockConfig    Config
+
+	endpoints *api_v1.Endpoints
+}
+
+// Get implements Interface.
+func (el *EndpointsLock) Get() (*LeaderElectionRecord, error) {
+	ep, err := el.Client.CoreV1().Endpoints(el.EndpointsMeta.Namespace).Get(el.EndpointsMeta.Name, meta_v1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	el.endpoints = ep
+
+	record := &LeaderElectionRecord{}
+	raw, found := ep.Annotations[LeaderElectionRecordAnnotationKey]
+	if !found {
+		return record, nil
+	}
+
+	if err := json.Unmarshal([]byte(raw), record); err != nil {
+		return nil, err
+	}
+
+	return record, nil
+}
+
+// Create implements Interface.
+func (el *EndpointsLock) Create(ler LeaderElectionRecord) error {
+	return el.update(ler)
+}
+
+// Update implements Interface.
+func (el *EndpointsLock) Update(ler LeaderElectionRecord) error {
+	return el.update(ler)
+}
+
+func (el *EndpointsLock) update(ler LeaderElectionRecord) error {
+	if el.endpoints == nil {
+		return fmt.Errorf("endpoints lock %v not initialized, call Get() first", el.Describe())
+	}
+
+	raw, err := json.Marshal(ler)
+	if err != nil {
+		return err
+	}
+
+	if el.endpoints.Annotations == nil {
+		el.endpoints.Annotations = make(map[string]string)
+	}
+	el.endpoints.Annotations[LeaderElectionRecordAnnotationKey] = string(raw)
+
+	updated, err := el.Client.CoreV1().Endpoints(el.EndpointsMeta.Namespace).Update(el.endpoints)
+	if err != nil {
+		return err
+	}
+	el.endpoints = updated
+
+	return nil
+}
+
+// Identity implements Interface.
+func (el *EndpointsLock) Identity() string {
+	return el.LockConfig.Identity
+}
+
+// Describe implements Interface.
+func (el *EndpointsLock) Describe() string {
+	return fmt.Sprintf("%v/%v (endpoints)", el.EndpointsMeta.Namespace, el.EndpointsMeta.Name)
+}
@@ -0,0 +1,114 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resourcelock
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// LeaderStore is implemented by external key/value backends (etcd, Consul,
+// ...) so a controller running outside of a Kubernetes cluster can still
+// perform leader election without talking to the API server.
+type LeaderStore interface {
+	// Get returns the value currently stored under key, or "" if unset.
+	Get(key string) (string, error)
+	// CAS atomically replaces oldValue with newValue under key with the
+	// given TTL, failing if the stored value no longer matches oldValue.
+	CAS(key, oldValue, newValue string, ttl time.Duration) (bool, error)
+}
+
+// ExternalLock adapts a LeaderStore to Interface so it can be driven by
+// the same election loop as the Kubernetes-backed locks.
+type ExternalLock struct {
+	Key        string
+	Store      LeaderStore
+	LockConfig Config
+
+	raw string
+}
+
+// Get implements Interface.
+func (el *ExternalLock) Get() (*LeaderElectionRecord, error) {
+	raw, err := el.Store.Get(el.Key)
+	if err != nil {
+		return nil, err
+	}
+	el.raw = raw
+
+	record := &LeaderElectionRecord{}
+	if raw == "" {
+		return record, nil
+	}
+
+	if err := json.Unmarshal([]byte(raw), record); err != nil {
+		return nil, err
+	}
+
+	return record, nil
+}
+
+// Create implements Interface.
+func (el *ExternalLock) Create(ler LeaderElectionRecord) error {
+	return el.swap(ler)
+}
+
+// Update implements Interface.
+func (el *ExternalLock) Update(ler LeaderElectionRecord) error {
+	return el.swap(ler)
+}
+
+func (el *ExternalLock) swap(ler LeaderElectionRecord) error {
+	raw, err := json.Marshal(ler)
+	if err != nil {
+		return err
+	}
+
+	ttl := time.Duration(ler.LeaseDurationSeconds) * time.Second
+	ok, err := el.Store.CAS(el.Key, el.raw, string(raw), ttl)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errNotCurrentOwner(el.Key)
+	}
+	el.raw = string(raw)
+
+	return nil
+}
+
+// Identity implements Interface.
+func (el *ExternalLock) Identity() string {
+	return el.LockConfig.Identity
+}
+
+// Describe implements Interface.
+func (el *ExternalLock) Describe() string {
+	return el.Key + " (external)"
+}
+
+func errNotCurrentOwner(key string) error {
+	return &notCurrentOwnerError{key}
+}
+
+type notCurrentOwnerError struct {
+	key string
+}
+
+func (e *notCurrentOwnerError) Error() string {
+	return "lock " + e.key + " was modified since it was last read"
+}
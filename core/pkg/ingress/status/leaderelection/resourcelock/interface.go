@@ -0,0 +1,106 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package resourcelock abstracts the object a leader election record is
+// stored in, so the election loop in the status package does not need to
+// know whether it is reading an Endpoints annotation, a ConfigMap
+// annotation, a coordination.k8s.io Lease or an entry in an external
+// key/value store.
+package resourcelock
+
+import (
+	"fmt"
+
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// LeaderElectionRecordAnnotationKey is the annotation the Endpoints and
+// ConfigMaps backends use to store the LeaderElectionRecord.
+const LeaderElectionRecordAnnotationKey = "control-plane.alpha.kubernetes.io/leader"
+
+// LockType identifies which backend a leader election lock is stored in.
+type LockType string
+
+const (
+	// LockTypeEndpoints keeps the lock on an Endpoints object. Kept for
+	// clusters that have not migrated to Leases yet.
+	LockTypeEndpoints LockType = "endpoints"
+	// LockTypeConfigMaps keeps the lock on a ConfigMap object.
+	LockTypeConfigMaps LockType = "configmaps"
+	// LockTypeLeases keeps the lock on a coordination.k8s.io/v1 Lease,
+	// the mechanism recommended since Kubernetes 1.14.
+	LockTypeLeases LockType = "leases"
+	// LockTypeExternal keeps the lock in a caller-supplied LeaderStore,
+	// for controllers running election outside of a cluster.
+	LockTypeExternal LockType = "external"
+)
+
+// LeaderElectionRecord is the payload stored by every lock backend. Its
+// JSON shape mirrors the annotation historically written to the Endpoints
+// object so existing tooling that inspects it keeps working.
+type LeaderElectionRecord struct {
+	HolderIdentity       string       `json:"holderIdentity"`
+	LeaseDurationSeconds int          `json:"leaseDurationSeconds"`
+	AcquireTime          meta_v1.Time `json:"acquireTime"`
+	RenewTime            meta_v1.Time `json:"renewTime"`
+	LeaderTransitions    int          `json:"leaderTransitions"`
+}
+
+// Interface is implemented by every supported lock backend.
+type Interface interface {
+	// Get returns the LeaderElectionRecord currently stored. A record
+	// with an empty HolderIdentity means no leader has been recorded
+	// yet; it is not an error.
+	Get() (*LeaderElectionRecord, error)
+	// Create stores ler as the initial record.
+	Create(ler LeaderElectionRecord) error
+	// Update replaces the previously Get-ed record with ler.
+	Update(ler LeaderElectionRecord) error
+	// Identity returns the identity this lock was configured with.
+	Identity() string
+	// Describe returns a human readable name for the lock, for logging.
+	Describe() string
+}
+
+// Config carries the parts of a lock's configuration that are common to
+// every backend.
+type Config struct {
+	Identity string
+}
+
+// New builds the Interface for lockType. kubeClient is used by every
+// backend except LockTypeExternal, which instead requires a non-nil
+// store.
+func New(lockType LockType, namespace, name string, kubeClient kubernetes.Interface, store LeaderStore, cfg Config) (Interface, error) {
+	meta := meta_v1.ObjectMeta{Namespace: namespace, Name: name}
+
+	switch lockType {
+	case LockTypeEndpoints:
+		return &EndpointsLock{EndpointsMeta: meta, Client: kubeClient, LockConfig: cfg}, nil
+	case LockTypeConfigMaps:
+		return &ConfigMapLock{ConfigMapMeta: meta, Client: kubeClient, LockConfig: cfg}, nil
+	case LockTypeLeases:
+		return &LeaseLock{LeaseMeta: meta, Client: kubeClient, LockConfig: cfg}, nil
+	case LockTypeExternal:
+		if store == nil {
+			return nil, fmt.Errorf("lock type %v requires a non-nil LeaderStore", lockType)
+		}
+		return &ExternalLock{Key: namespace + "/" + name, Store: store, LockConfig: cfg}, nil
+	}
+
+	return nil, fmt.Errorf("invalid lock type: %v", lockType)
+}
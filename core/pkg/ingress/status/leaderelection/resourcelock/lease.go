@@ -0,0 +1,127 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resourcelock
+
+import (
+	"fmt"
+
+	coordinationv1 "k8s.io/api/coordination/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// LeaseLock stores the LeaderElectionRecord as a coordination.k8s.io/v1
+// Lease, the mechanism recommended for leader election since Kubernetes
+// 1.14: it is a dedicated, lightweight object instead of an annotation on
+// something watched for unrelated reasons.
+type LeaseLock struct {
+	LeaseMeta  meta_v1.ObjectMeta
+	Client     kubernetes.Interface
+	LockConfig Config
+
+	lease *coordinationv1.Lease
+}
+
+// Get implements Interface.
+func (ll *LeaseLock) Get() (*LeaderElectionRecord, error) {
+	lease, err := ll.Client.CoordinationV1().Leases(ll.LeaseMeta.Namespace).Get(ll.LeaseMeta.Name, meta_v1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	ll.lease = lease
+
+	return leaseSpecToRecord(&lease.Spec), nil
+}
+
+// Create implements Interface.
+func (ll *LeaseLock) Create(ler LeaderElectionRecord) error {
+	lease := &coordinationv1.Lease{
+		ObjectMeta: ll.LeaseMeta,
+		Spec:       *recordToLeaseSpec(&ler),
+	}
+
+	created, err := ll.Client.CoordinationV1().Leases(ll.LeaseMeta.Namespace).Create(lease)
+	if err != nil {
+		return err
+	}
+	ll.lease = created
+
+	return nil
+}
+
+// Update implements Interface.
+func (ll *LeaseLock) Update(ler LeaderElectionRecord) error {
+	if ll.lease == nil {
+		return fmt.Errorf("lease lock %v not initialized, call Get() first", ll.Describe())
+	}
+
+	ll.lease.Spec = *recordToLeaseSpec(&ler)
+	updated, err := ll.Client.CoordinationV1().Leases(ll.LeaseMeta.Namespace).Update(ll.lease)
+	if err != nil {
+		return err
+	}
+	ll.lease = updated
+
+	return nil
+}
+
+// Identity implements Interface.
+func (ll *LeaseLock) Identity() string {
+	return ll.LockConfig.Identity
+}
+
+// Describe implements Interface.
+func (ll *LeaseLock) Describe() string {
+	return fmt.Sprintf("%v/%v (lease)", ll.LeaseMeta.Namespace, ll.LeaseMeta.Name)
+}
+
+func leaseSpecToRecord(spec *coordinationv1.LeaseSpec) *LeaderElectionRecord {
+	record := &LeaderElectionRecord{}
+
+	if spec.HolderIdentity != nil {
+		record.HolderIdentity = *spec.HolderIdentity
+	}
+	if spec.LeaseDurationSeconds != nil {
+		record.LeaseDurationSeconds = int(*spec.LeaseDurationSeconds)
+	}
+	if spec.AcquireTime != nil {
+		record.AcquireTime = meta_v1.NewTime(spec.AcquireTime.Time)
+	}
+	if spec.RenewTime != nil {
+		record.RenewTime = meta_v1.NewTime(spec.RenewTime.Time)
+	}
+	if spec.LeaseTransitions != nil {
+		record.LeaderTransitions = int(*spec.LeaseTransitions)
+	}
+
+	return record
+}
+
+func recordToLeaseSpec(record *LeaderElectionRecord) *coordinationv1.LeaseSpec {
+	leaseDurationSeconds := int32(record.LeaseDurationSeconds)
+	leaseTransitions := int32(record.LeaderTransitions)
+	acquireTime := meta_v1.NewMicroTime(record.AcquireTime.Time)
+	renewTime := meta_v1.NewMicroTime(record.RenewTime.Time)
+
+	return &coordinationv1.LeaseSpec{
+		HolderIdentity:       &record.HolderIdentity,
+		LeaseDurationSeconds: &leaseDurationSeconds,
+		AcquireTime:          &acquireTime,
+		RenewTime:            &renewTime,
+		LeaseTransitions:     &leaseTransitions,
+	}
+}
@@ -0,0 +1,49 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package ingress defines the intermediate representation the controller
+// builds from Ingress resources before handing it to a template.
+package ingress
+
+import "k8s.io/ingressl4/core/pkg/ingress/annotations/backendprotocol"
+
+// Backend describes one upstream a template needs to render: where its
+// endpoints live and which per-location annotations apply to it.
+type Backend struct {
+	Name    string
+	Service string
+	Port    string
+
+	Secure          bool
+	SSLPassthrough  bool
+	SessionAffinity string
+
+	// Protocol is the wire protocol the template must use to proxy
+	// traffic to this backend (HTTP, HTTPS, GRPC, GRPCS, H2C, FCGI or
+	// AJP). Its zero value is empty; annotationExtractor.BackendProtocol
+	// resolves an Ingress without a backend-protocol annotation to HTTP,
+	// or to HTTPS when secure-upstream is set, for backwards compatibility.
+	Protocol backendprotocol.Protocol
+
+	Endpoints []string
+
+	// AlternativeBackends lists the names of backends that should
+	// receive a share of this backend's traffic, per the
+	// ingress.kubernetes.io/canary annotations on the Ingress that
+	// defines them. It is empty unless another Ingress shares this
+	// backend's host+path and is marked canary.
+	AlternativeBackends []string
+}